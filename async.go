@@ -0,0 +1,306 @@
+package rwc
+
+import (
+	"io"
+	"sync"
+)
+
+// asyncBufSize is the size of the buffer the read pump allocates for each
+// call to the underlying Read.
+const asyncBufSize = 8 * 1024
+
+// asyncResult carries the outcome of one pumped Read or Write call.
+type asyncResult struct {
+	data []byte
+	err  error
+}
+
+// asyncWriteReq is a single Write request handed to the write pump.
+type asyncWriteReq struct {
+	data []byte
+	resp chan asyncResult
+}
+
+// asyncPump drives Read and Write against a single io.ReadWriteCloser from
+// dedicated goroutines, so that a blocked syscall never prevents Close or
+// Reset from unblocking a caller stuck in Read or Write. reads is the read
+// channel and closeCh is the close channel: closing closeCh tells both pump
+// goroutines to stop, and also wakes up any caller blocked waiting on reads
+// or writes.
+type asyncPump struct {
+	reads   chan asyncResult
+	writes  chan asyncWriteReq
+	closeCh chan struct{}
+	once    sync.Once
+
+	mu      sync.Mutex
+	reading bool          // true while rwc.Read is in flight; readLoop may block here indefinitely
+	exited  bool          // true once readLoop has returned for good, e.g. on a read error; nothing left to signal a future handoff
+	handoff chan struct{} // set by stop when reading and exited are both false, closed once readLoop resolves the read it already has
+	stopped bool
+	pending *asyncResult // a read that was completed but never delivered before stop
+	stopErr error        // the error stop was called with, returned to anyone unblocked by closeCh
+}
+
+// newAsyncPump starts a read pump and a write pump against rwc.
+func newAsyncPump(rwc io.ReadWriteCloser) *asyncPump {
+	p := &asyncPump{
+		reads:   make(chan asyncResult),
+		writes:  make(chan asyncWriteReq),
+		closeCh: make(chan struct{}),
+	}
+	go p.readLoop(rwc)
+	go p.writeLoop(rwc)
+	return p
+}
+
+// readLoop repeatedly reads up to asyncBufSize bytes from rwc and hands the
+// result to any caller waiting on reads. It exits once rwc.Read returns an
+// error, or once closeCh is closed. If closeCh closes before a completed
+// read could be delivered, the read is stashed in pending instead, so that
+// bytes already read off the wire aren't lost: a caller blocked on reads at
+// the moment of the close sees pending via the closeCh branch below, and a
+// caller that only calls Read afterwards (this pump is only reused across
+// a plain Close, not a Reset) finds it there too.
+//
+// reading tracks whether rwc.Read is currently in flight, which may block
+// indefinitely if the underlying io.ReadWriteCloser doesn't honor a
+// concurrent Close. stop only waits on handoff when reading is false and
+// readLoop hasn't already exited on its own, i.e. when readLoop is alive and
+// either already holds a completed result or is about to start reading, so
+// that a pending result is always stashed before stop returns, without
+// making stop itself wait on a read that may never return or on a goroutine
+// that is already gone.
+func (p *asyncPump) readLoop(rwc io.ReadWriteCloser) {
+	// However readLoop exits — stopped before starting a read, closeCh firing
+	// mid-select, or a plain error return after a successful delivery — stop
+	// may be waiting on handoff; signal it unconditionally on the way out,
+	// and record that readLoop is gone so a stop called afterward doesn't
+	// wait on a handoff nothing will ever close.
+	defer func() {
+		p.mu.Lock()
+		p.exited = true
+		p.signalHandoffLocked()
+		p.mu.Unlock()
+	}()
+
+	for {
+		p.mu.Lock()
+		if p.stopped {
+			p.mu.Unlock()
+			return
+		}
+		p.reading = true
+		p.mu.Unlock()
+
+		buf := make([]byte, asyncBufSize)
+		n, err := rwc.Read(buf)
+		res := asyncResult{buf[:n], err}
+
+		p.mu.Lock()
+		p.reading = false
+		if p.stopped {
+			p.pending = &res
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+
+		select {
+		case p.reads <- res:
+		case <-p.closeCh:
+			p.mu.Lock()
+			p.pending = &res
+			p.mu.Unlock()
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// signalHandoffLocked closes handoff if stop is waiting on it. Callers must
+// hold p.mu.
+func (p *asyncPump) signalHandoffLocked() {
+	if p.handoff != nil {
+		close(p.handoff)
+		p.handoff = nil
+	}
+}
+
+// writeLoop serializes Write calls to rwc, one asyncWriteReq at a time.
+//
+// Unlike readLoop, a write result that loses the race to deliver on
+// req.resp when closeCh closes has nowhere to go: reads share one pending
+// slot that any later Read call can pick up, but each Write call owns a
+// fresh, one-shot resp channel that only that same (already-returned) call
+// could ever receive from. So, unlike Read, a Write that is in flight when
+// Close or Reset fires can succeed against the underlying io.ReadWriteCloser
+// while its caller still observes the reset/close error and a short count,
+// with no way to recover the true outcome afterward. Callers that need
+// certainty here should confirm delivery some other way, such as a
+// protocol-level acknowledgement, rather than relying on Write's return
+// values alone across a concurrent Close or Reset.
+func (p *asyncPump) writeLoop(rwc io.ReadWriteCloser) {
+	for {
+		select {
+		case req := <-p.writes:
+			n, err := rwc.Write(req.data)
+			select {
+			case req.resp <- asyncResult{req.data[:n], err}:
+			case <-p.closeCh:
+				return
+			}
+		case <-p.closeCh:
+			return
+		}
+	}
+}
+
+// stop signals both pump goroutines to exit and unblocks anyone waiting on
+// reads or writes with err. It is safe to call stop more than once; only the
+// err from the first call is kept.
+//
+// If readLoop is alive and isn't currently blocked inside rwc.Read (i.e. it
+// already holds a completed read, or hasn't started its next one yet), stop
+// waits for it to resolve that read (deliver it on reads, or stash it in
+// pending) before returning. This closes a race where a Read called
+// immediately after stop returns would otherwise see closeCh already closed
+// but pending not yet set, and wrongly observe 0 bytes. If readLoop is
+// blocked inside rwc.Read, stop does not wait for it, since that call may
+// never return; likewise if readLoop has already exited on its own (e.g. a
+// prior Read returned an error), since there both is nothing left to stash
+// and no one left to signal the wait.
+func (p *asyncPump) stop(err error) {
+	p.once.Do(func() {
+		p.mu.Lock()
+		p.stopped = true
+		p.stopErr = err
+		var handoff chan struct{}
+		if !p.reading && !p.exited {
+			handoff = make(chan struct{})
+			p.handoff = handoff
+		}
+		p.mu.Unlock()
+
+		close(p.closeCh)
+
+		if handoff != nil {
+			<-handoff
+		}
+	})
+}
+
+// err returns the error passed to stop, for a caller unblocked by closeCh to
+// report back to its own caller.
+func (p *asyncPump) err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopErr
+}
+
+// Options configures optional behavior for a ResReadWriteCloser.
+type Options struct {
+	// AsyncRead drives Read (and Write) through a background pump goroutine
+	// pair, so Close and Reset can unblock a caller stuck in Read or Write
+	// even when the wrapped io.ReadWriteCloser does not honor a concurrent
+	// Close, such as a raw os.File or a custom net.Conn.
+	//
+	// A Write that is still in flight when Close or Reset fires can report
+	// that error to its caller even though the bytes were already written
+	// to the old io.ReadWriteCloser; see the comment on writeLoop in
+	// async.go for why, unlike Read, this byte count cannot be recovered.
+	AsyncRead bool
+}
+
+// NewResReadWriteCloserAsync creates a resettable ReadWriteCloser whose Read
+// and Write calls are driven by a background pump, so Close and Reset can
+// unblock a caller stuck in Read even if rwc doesn't honor Close concurrently.
+// It is equivalent to NewResReadWriteCloserWithOptions(rwc, Options{AsyncRead: true}).
+func NewResReadWriteCloserAsync(rwc io.ReadWriteCloser) *ResReadWriteCloser {
+	return NewResReadWriteCloserWithOptions(rwc, Options{AsyncRead: true})
+}
+
+// NewResReadWriteCloserWithOptions creates a resettable ReadWriteCloser with
+// the behavior described by opts. If you attempt to create it with a nil
+// value, a runtime panic will occur, for the same reasons as NewResReadWriteCloser.
+func NewResReadWriteCloserWithOptions(rwc io.ReadWriteCloser, opts Options) *ResReadWriteCloser {
+	if rwc == nil {
+		panic("ResReadWriteCloser: nil io.ReadWriteCloser not permitted")
+	}
+	r := &ResReadWriteCloser{rwc: rwc, base: rwc}
+	if opts.AsyncRead {
+		r.pump = newAsyncPump(rwc)
+	}
+	return r
+}
+
+// readAsync services a Read call against r.pump instead of calling
+// r.rwc.Read directly, so a blocked underlying Read can be abandoned on
+// Close or Reset. Read already hands out any buffered leftover bytes before
+// calling here, so this only runs when there are none.
+func (r *ResReadWriteCloser) readAsync(p []byte) (int, error) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+
+	r.mu.RLock()
+	pump := r.pump
+	r.mu.RUnlock()
+
+	select {
+	case res, ok := <-pump.reads:
+		if !ok {
+			return 0, pump.err()
+		}
+		n := copy(p, res.data)
+		if n < len(res.data) {
+			r.leftover = append(r.leftover, res.data[n:]...)
+		}
+		return n, res.err
+	case <-pump.closeCh:
+		pump.mu.Lock()
+		pending := pump.pending
+		pump.pending = nil
+		pump.mu.Unlock()
+
+		if pending == nil || len(pending.data) == 0 {
+			return 0, pump.err()
+		}
+
+		n := copy(p, pending.data)
+		if n < len(pending.data) {
+			r.leftover = append(r.leftover, pending.data[n:]...)
+		}
+		return n, pump.err()
+	}
+}
+
+// writeAsync services a Write call against r.pump instead of calling
+// r.rwc.Write directly, so a blocked underlying Write can be abandoned on
+// Close or Reset. Concurrent callers are serialized by the pump's write
+// goroutine, one asyncWriteReq at a time.
+//
+// See the comment on writeLoop for a gap this implies: unlike readAsync,
+// writeAsync has no pending slot to recover a write that completed against
+// the underlying io.ReadWriteCloser but lost the race to report back before
+// Close or Reset fired; that write's byte count is lost to this caller.
+func (r *ResReadWriteCloser) writeAsync(p []byte) (int, error) {
+	r.mu.RLock()
+	pump := r.pump
+	r.mu.RUnlock()
+
+	resp := make(chan asyncResult, 1)
+	select {
+	case pump.writes <- asyncWriteReq{data: p, resp: resp}:
+	case <-pump.closeCh:
+		return 0, pump.err()
+	}
+
+	select {
+	case res := <-resp:
+		return len(res.data), res.err
+	case <-pump.closeCh:
+		return 0, pump.err()
+	}
+}
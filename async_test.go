@@ -0,0 +1,200 @@
+package rwc_test
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tech10/rwc"
+)
+
+// blockingRWC is an io.ReadWriteCloser whose Read blocks until either data is
+// pushed on data or Close is called, used to exercise Options.AsyncRead.
+type blockingRWC struct {
+	mu      sync.Mutex
+	data    chan []byte
+	unblock chan struct{}
+	closed  bool
+}
+
+func newBlockingRWC() *blockingRWC {
+	return &blockingRWC{data: make(chan []byte, 4), unblock: make(chan struct{})}
+}
+
+func (b *blockingRWC) Read(p []byte) (int, error) {
+	select {
+	case d := <-b.data:
+		return copy(p, d), nil
+	case <-b.unblock:
+		return 0, io.ErrClosedPipe
+	}
+}
+
+func (b *blockingRWC) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (b *blockingRWC) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.unblock)
+	}
+	return nil
+}
+
+// errOnReadRWC returns data alongside a non-nil error on every Read, the way
+// a real io.Reader often reports its final chunk alongside io.EOF.
+type errOnReadRWC struct {
+	data []byte
+}
+
+func (e *errOnReadRWC) Read(p []byte) (int, error)  { return copy(p, e.data), io.EOF }
+func (e *errOnReadRWC) Write(p []byte) (int, error) { return len(p), nil }
+func (e *errOnReadRWC) Close() error                { return nil }
+
+func TestAsyncCloseAfterReadLoopExitsOnReadError(t *testing.T) {
+	m := &errOnReadRWC{data: []byte("hi")}
+	r := rwc.NewResReadWriteCloserAsync(m)
+
+	buf := make([]byte, 2)
+	n, err := r.Read(buf)
+	if n != 2 || !errors.Is(err, io.EOF) || string(buf) != "hi" {
+		t.Fatalf("unexpected read result: n=%d err=%v buf=%s", n, err, buf)
+	}
+
+	// readLoop delivered its result and then exited on its own, because
+	// rwc.Read returned a non-nil error; there's no one left to signal
+	// stop's handoff wait on this path but stop itself. Close must still
+	// return promptly rather than waiting forever for a signal an
+	// already-exited readLoop can never send.
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- r.Close() }()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return after readLoop had already exited on a read error")
+	}
+}
+
+func TestAsyncReadWriteBasic(t *testing.T) {
+	m := newBlockingRWC()
+	r := rwc.NewResReadWriteCloserAsync(m)
+
+	n, err := r.Write([]byte("hi"))
+	if err != nil || n != 2 {
+		t.Fatalf("unexpected write result: n=%d, err=%v", n, err)
+	}
+
+	m.data <- []byte("hello")
+	buf := make([]byte, 5)
+	n, err = r.Read(buf)
+	if err != nil || n != 5 || string(buf) != "hello" {
+		t.Fatalf("unexpected read result: n=%d, err=%v, buf=%s", n, err, buf)
+	}
+}
+
+func TestAsyncCloseDuringBlockedRead(t *testing.T) {
+	m := newBlockingRWC()
+	r := rwc.NewResReadWriteCloserAsync(m)
+
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 4)
+		_, err := r.Read(buf)
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if err := r.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		// A plain Close, unlike a Reset, reports io.ErrClosedPipe rather
+		// than ErrRWCReset: no reset occurred, so ErrRWCReset would be
+		// misleading here.
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Read did not unblock after Close")
+	}
+}
+
+func TestAsyncResetDuringBlockedRead(t *testing.T) {
+	m1 := newBlockingRWC()
+	r := rwc.NewResReadWriteCloserAsync(m1)
+
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 4)
+		_, err := r.Read(buf)
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if err := r.Reset(newBlockingRWC(), true); err != nil {
+		t.Fatalf("unexpected reset error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, rwc.ErrRWCReset) {
+			t.Fatalf("expected ErrRWCReset, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Read did not unblock after Reset")
+	}
+}
+
+func TestAsyncPendingReadSurfacedAfterClose(t *testing.T) {
+	m := newBlockingRWC()
+	r := rwc.NewResReadWriteCloserAsync(m)
+
+	// Hand the pump a completed read with nobody yet calling Read, so
+	// readLoop blocks trying to deliver it on pump.reads until Close races
+	// it into stashing the result in pump.pending instead.
+	m.data <- []byte("hello")
+	time.Sleep(10 * time.Millisecond)
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	buf := make([]byte, 5)
+	n, err := r.Read(buf)
+	if n != 5 || string(buf) != "hello" {
+		t.Fatalf("expected pending bytes \"hello\" to be surfaced, got n=%d buf=%s", n, buf)
+	}
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+	}
+}
+
+func TestAsyncLeftoverBytesSurfaced(t *testing.T) {
+	m := newBlockingRWC()
+	r := rwc.NewResReadWriteCloserAsync(m)
+
+	m.data <- []byte("hello!")
+
+	buf := make([]byte, 3)
+	n, err := r.Read(buf)
+	if err != nil || n != 3 || string(buf) != "hel" {
+		t.Fatalf("unexpected first read: n=%d err=%v buf=%s", n, err, buf)
+	}
+
+	buf2 := make([]byte, 3)
+	n, err = r.Read(buf2)
+	if err != nil || n != 3 || string(buf2) != "lo!" {
+		t.Fatalf("unexpected second (leftover) read: n=%d err=%v buf=%s", n, err, buf2)
+	}
+}
@@ -0,0 +1,186 @@
+package rwc
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ReadContext behaves like Read, but allows the read to be cancelled via ctx.
+// The underlying Read is executed in a background goroutine, against a
+// private buffer rather than p, so that a blocked syscall does not prevent
+// ctx from cancelling the call and the goroutine never touches p once the
+// caller has regained it, as io.Reader requires. If ctx is done before the
+// underlying Read completes, ReadContext returns immediately with ctx.Err(),
+// leaving the goroutine to run to completion against the old
+// io.ReadWriteCloser; any bytes it eventually reads are buffered in the same
+// leftover slot a pumped async Read uses, so the next Read or ReadContext
+// call surfaces them instead of losing them silently.
+// If the ResReadWriteCloser is reset while the read is in flight,
+// ctx.Err() is joined with the current reset error (ErrRWCReset unless
+// overridden via ResetWithErr).
+func (r *ResReadWriteCloser) ReadContext(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if n, ok := r.takeLeftover(p); ok {
+		return n, nil
+	}
+
+	startCount := r.count.Load()
+
+	r.mu.RLock()
+	reader := r.rwc
+	r.mu.RUnlock()
+
+	if startCount != r.count.Load() {
+		return 0, r.currentResetErr()
+	}
+
+	priv := make([]byte, len(p))
+	done := make(chan asyncResult, 1)
+	go func() {
+		n, err := reader.Read(priv)
+		done <- asyncResult{priv[:n], err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		err := ctx.Err()
+		if startCount != r.count.Load() {
+			err = errors.Join(err, r.currentResetErr())
+		}
+		go r.stashLateRead(done)
+		return 0, err
+	case res := <-done:
+		n := copy(p, res.data)
+		if startCount != r.count.Load() {
+			return n, r.currentResetErr()
+		}
+		return n, res.err
+	}
+}
+
+// takeLeftover copies any buffered bytes left over from an earlier pumped or
+// context-cancelled read into p, reporting whether there were any.
+func (r *ResReadWriteCloser) takeLeftover(p []byte) (int, bool) {
+	r.readMu.Lock()
+	defer r.readMu.Unlock()
+	if len(r.leftover) == 0 {
+		return 0, false
+	}
+	n := copy(p, r.leftover)
+	r.leftover = r.leftover[n:]
+	return n, true
+}
+
+// stashLateRead waits for a Read that ReadContext already gave up on to
+// finish, and appends any bytes it returns to leftover, the same place a
+// pumped async Read buffers bytes that didn't fit a caller's slice, so a
+// later Read or ReadContext call still observes them instead of losing them
+// silently.
+func (r *ResReadWriteCloser) stashLateRead(done <-chan asyncResult) {
+	res := <-done
+	if len(res.data) == 0 {
+		return
+	}
+	r.readMu.Lock()
+	r.leftover = append(r.leftover, res.data...)
+	r.readMu.Unlock()
+}
+
+// WriteContext behaves like Write, but allows the write to be cancelled via
+// ctx. It mirrors ReadContext: the underlying Write runs in a background
+// goroutine against a private copy of p, rather than p itself, so a blocked
+// syscall can be abandoned by the caller without the goroutine retaining a
+// reference to a slice the caller is now free to reuse, as io.Writer
+// requires. If ctx fires first, WriteContext returns early with ctx.Err()
+// while the goroutine completes against the old io.ReadWriteCloser in the
+// background; as with a Write abandoned by Options.AsyncRead (see the
+// comment on writeLoop in async.go), there is no way to report that write's
+// outcome back to a caller that has already returned.
+func (r *ResReadWriteCloser) WriteContext(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	startCount := r.count.Load()
+
+	r.mu.RLock()
+	writer := r.rwc
+	r.mu.RUnlock()
+
+	if startCount != r.count.Load() {
+		return 0, r.currentResetErr()
+	}
+
+	priv := append([]byte(nil), p...)
+	done := make(chan asyncResult, 1)
+	go func() {
+		n, err := writer.Write(priv)
+		done <- asyncResult{priv[:n], err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		err := ctx.Err()
+		if startCount != r.count.Load() {
+			err = errors.Join(err, r.currentResetErr())
+		}
+		return 0, err
+	case res := <-done:
+		n := len(res.data)
+		if startCount != r.count.Load() {
+			return n, r.currentResetErr()
+		}
+		return n, res.err
+	}
+}
+
+// ResetContext behaves like Reset, but allows the caller to abandon waiting
+// on a slow Close of the old io.ReadWriteCloser via ctx. The swap to newRWC
+// itself is not cancellable once ErrResetNil, ErrEqualToSelf and ErrEqual
+// have been ruled out; only the closeOld Close call is run in a background
+// goroutine and raced against ctx.Done().
+func (r *ResReadWriteCloser) ResetContext(ctx context.Context, newRWC io.ReadWriteCloser, closeOld bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	switch newRWC {
+	case nil:
+		return ErrResetNil
+	case r:
+		return ErrEqualToSelf
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.base == newRWC {
+		return ErrEqual
+	}
+
+	// Close the old raw base rather than the old wrapper: a middleware's Wrap
+	// may return the same value re-pointed at newRWC.
+	old := r.base
+	r.base = newRWC
+	r.applyReset(applyMiddleware(newRWC, r.mw), nil)
+
+	if !closeOld {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- old.Close()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
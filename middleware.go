@@ -0,0 +1,46 @@
+package rwc
+
+import "io"
+
+// Middleware wraps an io.ReadWriteCloser with another one, for example to
+// add rate limiting, byte counting, or logging. See the rwc/middleware
+// sub-package for a small set of ready-made implementations.
+type Middleware func(io.ReadWriteCloser) io.ReadWriteCloser
+
+// applyMiddleware wraps base with each middleware in mw, in order, so the
+// last middleware in mw ends up the outermost wrapper and is the first to
+// see each Read, Write or Close call.
+func applyMiddleware(base io.ReadWriteCloser, mw []Middleware) io.ReadWriteCloser {
+	wrapped := base
+	for _, m := range mw {
+		wrapped = m(wrapped)
+	}
+	return wrapped
+}
+
+// NewResReadWriteCloserWithMiddleware creates a resettable ReadWriteCloser
+// whose effective io.ReadWriteCloser is base wrapped by each of mw, in
+// order. Resetting the returned ResReadWriteCloser with Reset or
+// ResetWithErr re-applies the same middleware chain over the new base, so
+// RWC always returns the outermost wrapper and Base always returns the raw
+// value most recently passed to Reset (or to this constructor).
+func NewResReadWriteCloserWithMiddleware(base io.ReadWriteCloser, mw ...Middleware) *ResReadWriteCloser {
+	if base == nil {
+		panic("ResReadWriteCloser: nil io.ReadWriteCloser not permitted")
+	}
+	r := &ResReadWriteCloser{base: base, mw: mw, rwc: applyMiddleware(base, mw)}
+	return r
+}
+
+// Base returns the raw io.ReadWriteCloser most recently passed to
+// NewResReadWriteCloserWithMiddleware or to Reset/ResetWithErr, before any
+// middleware was applied. For a ResReadWriteCloser created without
+// middleware, Base returns the same value as RWC.
+func (r *ResReadWriteCloser) Base() io.ReadWriteCloser {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.base != nil {
+		return r.base
+	}
+	return r.rwc
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Limiter is an io.ReadWriteCloser middleware enforcing a token-bucket rate
+// limit, in bytes per second, shared between Read and Write. It is safe for
+// concurrent use, including a Wrap call racing an in-flight Read or Write,
+// as happens when a rwc.ResReadWriteCloser carrying this Limiter is reset
+// concurrently.
+type Limiter struct {
+	bucket *tokenBucket
+
+	mu   sync.RWMutex
+	next io.ReadWriteCloser
+}
+
+// NewLimiter returns a Limiter allowing bytesPerSecond bytes per second,
+// with a burst allowance of burst bytes. If burst is 0, it defaults to
+// bytesPerSecond. Its Wrap method should be passed to
+// rwc.NewResReadWriteCloserWithMiddleware.
+func NewLimiter(bytesPerSecond, burst int) *Limiter {
+	return &Limiter{bucket: newTokenBucket(bytesPerSecond, burst)}
+}
+
+// Wrap implements rwc.Middleware.
+func (l *Limiter) Wrap(next io.ReadWriteCloser) io.ReadWriteCloser {
+	l.mu.Lock()
+	l.next = next
+	l.mu.Unlock()
+	return l
+}
+
+// Read implements the io.Reader interface, blocking until len(p) bytes, or
+// the burst size if that is smaller, are available in the token bucket
+// before reading.
+func (l *Limiter) Read(p []byte) (int, error) {
+	l.bucket.take(len(p))
+	l.mu.RLock()
+	next := l.next
+	l.mu.RUnlock()
+	return next.Read(p)
+}
+
+// Write implements the io.Writer interface, blocking until len(p) bytes, or
+// the burst size if that is smaller, are available in the token bucket
+// before writing.
+func (l *Limiter) Write(p []byte) (int, error) {
+	l.bucket.take(len(p))
+	l.mu.RLock()
+	next := l.next
+	l.mu.RUnlock()
+	return next.Write(p)
+}
+
+// Close implements the io.Closer interface.
+func (l *Limiter) Close() error {
+	l.mu.RLock()
+	next := l.next
+	l.mu.RUnlock()
+	return next.Close()
+}
+
+// tokenBucket is a simple, mutex-guarded token-bucket rate limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   int
+	burst  int
+	tokens int
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = rate
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take blocks until n tokens (capped at the bucket's burst size) are
+// available, then consumes them.
+func (b *tokenBucket) take(n int) {
+	if n > b.burst {
+		n = b.burst
+	}
+	for {
+		b.mu.Lock()
+		b.refill()
+		if b.tokens >= n {
+			b.tokens -= n
+			b.mu.Unlock()
+			return
+		}
+		wait := time.Duration(float64(n-b.tokens) / float64(b.rate) * float64(time.Second))
+		b.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// refill adds tokens accrued since the last call, capped at the burst size.
+// Callers must hold b.mu.
+func (b *tokenBucket) refill() {
+	now := time.Now()
+	elapsed := now.Sub(b.last)
+	b.last = now
+	if add := int(elapsed.Seconds() * float64(b.rate)); add > 0 {
+		b.tokens += add
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+}
@@ -6,6 +6,7 @@ package rwc
 import (
 	"io"
 	"sync"
+	stdatomic "sync/atomic"
 
 	"github.com/tech10/rwc/atomic"
 )
@@ -17,15 +18,31 @@ import (
 // the read or write operation will complete on the old ReadWriteCloser unless the old ReadWriteCloser is closed.
 //
 // If the io.ReadWriteCloser is reset during a read or write call,
-// ErrRWCReset is returned along with the number of bytes read or written by the old reader.
-// Callers of Read or Write should check for the presence of ErrRWCReset,
+// ErrRWCReset is returned along with the number of bytes read or written by the old reader,
+// unless ResetWithErr was used to supply a different error for callers to observe instead.
+// Callers of Read or Write should check for the presence of ErrRWCReset (or their custom error),
 // and if desired, try their calls again.
 // Callers should also check the number of bytes read or written
 // on the old io.ReadWriteCloser after the reset occurred.
 type ResReadWriteCloser struct {
-	mu    sync.RWMutex
-	rwc   io.ReadWriteCloser
-	count atomic.Uint64 // increments on every reset
+	mu       sync.RWMutex
+	rwc      io.ReadWriteCloser
+	count    atomic.Uint64   // increments on every reset
+	resetErr stdatomic.Value // holds resetError, the error observed after the most recent reset
+
+	pump     *asyncPump // non-nil when created with Options.AsyncRead
+	readMu   sync.Mutex // serializes readAsync and access to leftover
+	leftover []byte     // buffered bytes from a pumped read, or a read ReadContext gave up on, that the caller hasn't consumed yet
+
+	base io.ReadWriteCloser // the raw io.ReadWriteCloser, before any middleware was applied
+	mw   []Middleware       // the middleware chain applied over base to produce rwc
+}
+
+// resetError wraps the error an in-flight Read or Write should observe after
+// a reset, so that a nil error can still be stored in the stdatomic.Value
+// (which otherwise cannot hold a nil interface).
+type resetError struct {
+	err error
 }
 
 // NewResReadWriteCloser creates a resettable ReadWriteCloser implementing the io.ReadWriteCloser interface.
@@ -36,33 +53,46 @@ func NewResReadWriteCloser(rwc io.ReadWriteCloser) *ResReadWriteCloser {
 	if rwc == nil {
 		panic("ResReadWriteCloser: nil io.ReadWriteCloser not permitted")
 	}
-	return &ResReadWriteCloser{rwc: rwc}
+	return &ResReadWriteCloser{rwc: rwc, base: rwc}
 }
 
 // Read implements the io.Reader interface.
 // If the ReadWriteCloser is reset during a read,
-// ErrRWCReset is returned along with the number of bytes read from the previous ReadWriteCloser.
+// ErrRWCReset is returned along with the number of bytes read from the previous ReadWriteCloser,
+// unless a custom error was supplied via ResetWithErr, in which case that error is returned instead.
 // Any error returned by the io.ReadWriteCloser after the reset
-// is replaced with ErrRWCReset.
+// is replaced the same way.
 // If it is reset before the read takes place,
-// 0 is returned along with ErrRWCReset.
+// 0 is returned along with the reset error.
+// Any bytes left over from a pumped async read, or from a read that
+// completed after a ReadContext call had already given up on it, are handed
+// out first.
 func (r *ResReadWriteCloser) Read(p []byte) (int, error) {
+	if n, ok := r.takeLeftover(p); ok {
+		return n, nil
+	}
+
 	startCount := r.count.Load()
 
 	r.mu.RLock()
 	reader := r.rwc
+	pump := r.pump
 	r.mu.RUnlock()
 
+	if pump != nil {
+		return r.readAsync(p)
+	}
+
 	// Detect reset before starting
 	if startCount != r.count.Load() {
-		return 0, ErrRWCReset
+		return 0, r.currentResetErr()
 	}
 
 	n, err := reader.Read(p)
 
 	// Detect reset after read
 	if startCount != r.count.Load() {
-		return n, ErrRWCReset
+		return n, r.currentResetErr()
 	}
 
 	return n, err
@@ -70,42 +100,63 @@ func (r *ResReadWriteCloser) Read(p []byte) (int, error) {
 
 // Write implements the io.Writer interface.
 // If the ReadWriteCloser is reset during a write,
-// ErrRWCReset is returned along with the number of bytes written to the previous ReadWriteCloser.
+// ErrRWCReset is returned along with the number of bytes written to the previous ReadWriteCloser,
+// unless a custom error was supplied via ResetWithErr, in which case that error is returned instead.
 // Any error returned by the io.ReadWriteCloser after the reset
-// is replaced with ErrRWCReset.
+// is replaced the same way.
 // If it is reset before the write takes place,
-// 0 is returned along with ErrRWCReset.
+// 0 is returned along with the reset error.
 func (r *ResReadWriteCloser) Write(p []byte) (int, error) {
 	startCount := r.count.Load()
 
 	r.mu.RLock()
 	writer := r.rwc
+	pump := r.pump
 	r.mu.RUnlock()
 
+	if pump != nil {
+		return r.writeAsync(p)
+	}
+
 	// Detect reset before starting
 	if startCount != r.count.Load() {
-		return 0, ErrRWCReset
+		return 0, r.currentResetErr()
 	}
 
 	n, err := writer.Write(p)
 
 	// Detect reset after write
 	if startCount != r.count.Load() {
-		return n, ErrRWCReset
+		return n, r.currentResetErr()
 	}
 
 	return n, err
 }
 
 // Close implements the io.Closer interface.
-// It closes the io.ReadWriteCloser assigned under a read mutex lock
-// to ensure a reset cannot occur until all read locks are released.
+// It reads the io.ReadWriteCloser assigned under a read mutex lock, then
+// releases the lock before calling Close on it, so a concurrent Reset is
+// free to swap in a new io.ReadWriteCloser while this call is in flight.
 // Whether or not to use the ResReadWriteCloser after close is left up to the caller.
 // It can be reset after Close is called.
+//
+// If the ResReadWriteCloser was created with Options.AsyncRead, Close also
+// stops the pump, which unblocks any caller stuck in Read or Write with
+// io.ErrClosedPipe, even if the underlying io.ReadWriteCloser doesn't honor
+// a concurrent Close. This is distinct from a Reset unblocking the same
+// caller, which reports ErrRWCReset (or the error supplied to ResetWithErr)
+// instead, since no reset has actually occurred.
 func (r *ResReadWriteCloser) Close() error {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return r.rwc.Close()
+	rwc := r.rwc
+	pump := r.pump
+	r.mu.RUnlock()
+
+	if pump != nil {
+		pump.stop(io.ErrClosedPipe)
+	}
+
+	return rwc.Close()
 }
 
 // Reset will allow you to reset the current io.ReadWriteCloser
@@ -122,7 +173,23 @@ func (r *ResReadWriteCloser) Close() error {
 // Setting closeOld to false could prove useful in certain situations,
 // such as resetting the ResReadWriteCloser with a custom ReadWriteCloser implementation
 // wrapping the one you originally used on creation.
+//
+// Reset is equivalent to calling ResetWithErr with a nil err, so in-flight
+// Read and Write calls observe ErrRWCReset.
 func (r *ResReadWriteCloser) Reset(newRWC io.ReadWriteCloser, closeOld bool) error {
+	return r.ResetWithErr(newRWC, closeOld, nil)
+}
+
+// ResetWithErr behaves like Reset, but lets the caller choose the error that
+// in-flight Read and Write calls observe once they detect this reset, instead
+// of the default ErrRWCReset. This is useful when the reason for the reset is
+// itself meaningful to callers, such as a dropped connection, a rotated
+// credential, or a requested shutdown, and they want errors.Is to match
+// against their own sentinel.
+//
+// Passing a nil err restores the default ErrRWCReset for this reset and any
+// that follow it, until overridden again.
+func (r *ResReadWriteCloser) ResetWithErr(newRWC io.ReadWriteCloser, closeOld bool, err error) error {
 	switch newRWC {
 	case nil:
 		return ErrResetNil
@@ -133,13 +200,18 @@ func (r *ResReadWriteCloser) Reset(newRWC io.ReadWriteCloser, closeOld bool) err
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.rwc == newRWC {
+	if r.base == newRWC {
 		return ErrEqual
 	}
 
 	old := r.rwc
-	r.rwc = newRWC
-	r.count.Add(1) // increment generation
+	if len(r.mw) > 0 {
+		// Close the old raw base rather than the old wrapper: a middleware's
+		// Wrap may return the same value re-pointed at newRWC.
+		old = r.base
+	}
+	r.base = newRWC
+	r.applyReset(applyMiddleware(newRWC, r.mw), err)
 
 	if closeOld {
 		_ = old.Close()
@@ -148,6 +220,34 @@ func (r *ResReadWriteCloser) Reset(newRWC io.ReadWriteCloser, closeOld bool) err
 	return nil
 }
 
+// currentResetErr returns the error that in-flight Read and Write calls
+// should observe after detecting a reset: the error supplied to the most
+// recent ResetWithErr call, or ErrRWCReset if none was supplied or the
+// ResReadWriteCloser has never been reset. It exists as a single point for
+// ReadContext, WriteContext and ResetContext to share with Read and Write.
+func (r *ResReadWriteCloser) currentResetErr() error {
+	if v, ok := r.resetErr.Load().(resetError); ok && v.err != nil {
+		return v.err
+	}
+	return ErrRWCReset
+}
+
+// applyReset swaps in newRWC, stores the error in-flight Read and Write calls
+// should observe, bumps the generation counter, and, if running in async
+// mode, stops the old pump and starts a new one against newRWC.
+// Callers must hold r.mu for writing.
+func (r *ResReadWriteCloser) applyReset(newRWC io.ReadWriteCloser, resetErr error) {
+	r.rwc = newRWC
+	r.resetErr.Store(resetError{resetErr})
+	r.count.Add(1) // increment generation
+
+	if r.pump != nil {
+		oldPump := r.pump
+		r.pump = newAsyncPump(newRWC)
+		oldPump.stop(r.currentResetErr())
+	}
+}
+
 // ResetCount returns the number of times the ResReadWriteCloser has been reset.
 // This could be useful for debugging, testing, or if you chose to set up your own limits for resets.
 func (r *ResReadWriteCloser) ResetCount() uint64 {
@@ -157,6 +257,8 @@ func (r *ResReadWriteCloser) ResetCount() uint64 {
 // RWC returns the underlying io.ReadWriteCloser.
 // If you initialized the ResReadWriteCloser with something like a net.Conn or os.File,
 // you can retrieve the original value via type assertion.
+// If the ResReadWriteCloser was created with middleware, RWC returns the
+// outermost wrapped value; use Base to get the raw value underneath.
 func (r *ResReadWriteCloser) RWC() io.ReadWriteCloser {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -1,7 +1,7 @@
 package rwc_test
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"io"
 	"sync"
@@ -9,53 +9,9 @@ import (
 	"time"
 
 	"github.com/tech10/rwc"
+	"github.com/tech10/rwc/rwctest"
 )
 
-// mockRWC wraps a bytes.Buffer to implement io.ReadWriteCloser.
-type mockRWC struct {
-	buf    *bytes.Buffer
-	delay  time.Duration
-	closed bool
-	mu     sync.Mutex
-}
-
-func newMockRWC() *mockRWC {
-	return &mockRWC{buf: &bytes.Buffer{}}
-}
-
-func (m *mockRWC) Read(p []byte) (int, error) {
-	m.waitDelay()
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.closed {
-		return 0, io.ErrClosedPipe
-	}
-	return m.buf.Read(p)
-}
-
-func (m *mockRWC) Write(p []byte) (int, error) {
-	m.waitDelay()
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.closed {
-		return 0, io.ErrClosedPipe
-	}
-	return m.buf.Write(p)
-}
-
-func (m *mockRWC) Close() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.closed = true
-	return nil
-}
-
-func (m *mockRWC) waitDelay() {
-	if m.delay > 0 {
-		time.Sleep(m.delay)
-	}
-}
-
 func TestNewResReadWriteCloser_PanicOnNil(t *testing.T) {
 	defer func() {
 		if r := recover(); r == nil {
@@ -66,7 +22,7 @@ func TestNewResReadWriteCloser_PanicOnNil(t *testing.T) {
 }
 
 func TestReadWriteBasic(t *testing.T) {
-	m := newMockRWC()
+	m := rwctest.NewBufRWC()
 	r := rwc.NewResReadWriteCloser(m)
 
 	data := []byte("hello")
@@ -89,8 +45,8 @@ func TestReadWriteBasic(t *testing.T) {
 }
 
 func TestResetErrors(t *testing.T) {
-	m1 := newMockRWC()
-	m2 := newMockRWC()
+	m1 := rwctest.NewBufRWC()
+	m2 := rwctest.NewBufRWC()
 	r := rwc.NewResReadWriteCloser(m1)
 
 	// Reset with nil
@@ -119,8 +75,8 @@ func TestResetErrors(t *testing.T) {
 }
 
 func TestResetCloseOld(t *testing.T) {
-	m1 := newMockRWC()
-	m2 := newMockRWC()
+	m1 := rwctest.NewBufRWC()
+	m2 := rwctest.NewBufRWC()
 	r := rwc.NewResReadWriteCloser(m1)
 
 	if err := r.Reset(m2, true); err != nil {
@@ -142,7 +98,7 @@ func TestResetCloseOld(t *testing.T) {
 }
 
 func TestConcurrentReadWrite(t *testing.T) {
-	m := newMockRWC()
+	m := rwctest.NewBufRWC()
 	r := rwc.NewResReadWriteCloser(m)
 
 	var wg sync.WaitGroup
@@ -164,7 +120,7 @@ func TestConcurrentReadWrite(t *testing.T) {
 }
 
 func TestClose(t *testing.T) {
-	m := newMockRWC()
+	m := rwctest.NewBufRWC()
 	r := rwc.NewResReadWriteCloser(m)
 
 	if err := r.Close(); err != nil {
@@ -180,8 +136,7 @@ func TestClose(t *testing.T) {
 }
 
 func TestDelayedReadWriteDuringReset(t *testing.T) {
-	m := newMockRWC()
-	m.delay = 50 * time.Millisecond
+	m := rwctest.NewDelayRWC(rwctest.NewBufRWC(), 50*time.Millisecond)
 	r := rwc.NewResReadWriteCloser(m)
 
 	var wg sync.WaitGroup
@@ -222,9 +177,234 @@ func TestDelayedReadWriteDuringReset(t *testing.T) {
 
 	// Reset while operations are still pending, do not close the previous ReadWriteCloser
 	// so full functionality can be tested properly.
-	if err := r.Reset(newMockRWC(), false); err != nil {
+	if err := r.Reset(rwctest.NewBufRWC(), false); err != nil {
 		t.Errorf("unexpected reset error: %v", err)
 	}
 
 	wg.Wait()
 }
+
+func TestReadWriteContextBasic(t *testing.T) {
+	m := rwctest.NewBufRWC()
+	r := rwc.NewResReadWriteCloser(m)
+
+	n, err := r.WriteContext(context.Background(), []byte("hello"))
+	if err != nil || n != 5 {
+		t.Fatalf("unexpected write result: n=%d, err=%v", n, err)
+	}
+
+	buf := make([]byte, 5)
+	n, err = r.ReadContext(context.Background(), buf)
+	if err != nil && !errors.Is(err, io.EOF) {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if n != 5 || string(buf) != "hello" {
+		t.Fatalf("unexpected read result: %s", buf)
+	}
+}
+
+func TestReadContextCancel(t *testing.T) {
+	m := rwctest.NewDelayRWC(rwctest.NewBufRWC(), 50*time.Millisecond)
+	r := rwc.NewResReadWriteCloser(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	buf := make([]byte, 4)
+	_, err := r.ReadContext(ctx, buf)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWriteContextCancel(t *testing.T) {
+	m := rwctest.NewDelayRWC(rwctest.NewBufRWC(), 50*time.Millisecond)
+	r := rwc.NewResReadWriteCloser(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	_, err := r.WriteContext(ctx, []byte("test"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestReadContextCancelDoesNotTouchCallerBuffer(t *testing.T) {
+	m := rwctest.NewDelayRWC(rwctest.NewBufRWC(), 50*time.Millisecond)
+	r := rwc.NewResReadWriteCloser(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	buf := make([]byte, 4)
+	if _, err := r.ReadContext(ctx, buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// The caller reclaims buf as soon as ReadContext returns; reuse it right
+	// away, the way a pooled read buffer would be. The abandoned background
+	// Read, which completes later against the private buffer ReadContext now
+	// gives it, must not still be writing into buf.
+	for i := range buf {
+		buf[i] = 0xAA
+	}
+	time.Sleep(100 * time.Millisecond)
+	for i, b := range buf {
+		if b != 0xAA {
+			t.Fatalf("buf[%d] was modified by an abandoned background read: %v", i, buf)
+		}
+	}
+}
+
+func TestReadContextCancelSurfacesLateBytes(t *testing.T) {
+	base := rwctest.NewBufRWC()
+	if _, err := base.Write([]byte("late")); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	m := rwctest.NewDelayRWC(base, 30*time.Millisecond)
+	r := rwc.NewResReadWriteCloser(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	buf := make([]byte, 4)
+	if _, err := r.ReadContext(ctx, buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	buf2 := make([]byte, 4)
+	n, err := r.Read(buf2)
+	if err != nil || n != 4 || string(buf2) != "late" {
+		t.Fatalf("expected late bytes %q to be surfaced by a later Read, got n=%d err=%v buf=%s", "late", n, err, buf2)
+	}
+}
+
+func TestWriteContextCancelDoesNotRetainCallerBuffer(t *testing.T) {
+	base := rwctest.NewBufRWC()
+	m := rwctest.NewDelayRWC(base, 50*time.Millisecond)
+	r := rwc.NewResReadWriteCloser(m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	data := []byte("test")
+	if _, err := r.WriteContext(ctx, data); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// WriteContext must not retain data: mutate it immediately, as a pooled
+	// write buffer would be reused, and confirm the background write that
+	// eventually completes still wrote the original bytes.
+	copy(data, "XXXX")
+
+	time.Sleep(100 * time.Millisecond)
+
+	got := make([]byte, 4)
+	n, err := base.Read(got)
+	if err != nil || n != 4 || string(got) != "test" {
+		t.Fatalf("expected underlying write to contain original bytes %q, got n=%d err=%v buf=%s", "test", n, err, got)
+	}
+}
+
+func TestResetWithErr(t *testing.T) {
+	m := rwctest.NewDelayRWC(rwctest.NewBufRWC(), 50*time.Millisecond)
+	r := rwc.NewResReadWriteCloser(m)
+
+	errShutdown := errors.New("shutdown requested")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		buf := make([]byte, 4)
+		_, err := r.Read(buf)
+		if !errors.Is(err, errShutdown) {
+			t.Errorf("expected %v, got %v", errShutdown, err)
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if err := r.ResetWithErr(rwctest.NewBufRWC(), false, errShutdown); err != nil {
+		t.Fatalf("unexpected reset error: %v", err)
+	}
+
+	wg.Wait()
+
+	// A reset without a custom error reverts to ErrRWCReset. Swap in a
+	// delayed RWC first so there's something to be blocked on, then reset
+	// again while the read is in flight.
+	m2 := rwctest.NewDelayRWC(rwctest.NewBufRWC(), 50*time.Millisecond)
+	if err := r.Reset(m2, false); err != nil {
+		t.Fatalf("unexpected reset error: %v", err)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		buf := make([]byte, 4)
+		_, err := r.Read(buf)
+		if !errors.Is(err, rwc.ErrRWCReset) {
+			t.Errorf("expected %v, got %v", rwc.ErrRWCReset, err)
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if err := r.Reset(rwctest.NewBufRWC(), false); err != nil {
+		t.Fatalf("unexpected reset error: %v", err)
+	}
+
+	wg.Wait()
+}
+
+func TestNewResReadWriteCloserWithMiddleware(t *testing.T) {
+	m := rwctest.NewBufRWC()
+	var calls []string
+	tagMW := func(tag string) rwc.Middleware {
+		return func(next io.ReadWriteCloser) io.ReadWriteCloser {
+			calls = append(calls, tag)
+			return next
+		}
+	}
+
+	r := rwc.NewResReadWriteCloserWithMiddleware(m, tagMW("inner"), tagMW("outer"))
+	if len(calls) != 2 || calls[0] != "inner" || calls[1] != "outer" {
+		t.Fatalf("expected middleware applied in order, got %v", calls)
+	}
+
+	if r.Base() != m {
+		t.Fatal("expected Base to return the original io.ReadWriteCloser")
+	}
+	if r.RWC() != m {
+		t.Fatal("expected RWC to return the wrapped value (no-op middleware here)")
+	}
+
+	m2 := rwctest.NewBufRWC()
+	calls = nil
+	if err := r.Reset(m2, true); err != nil {
+		t.Fatalf("unexpected reset error: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected middleware chain reapplied on reset, got %v", calls)
+	}
+	if r.Base() != m2 {
+		t.Fatal("expected Base to return the new base after reset")
+	}
+}
+
+func TestResetContext(t *testing.T) {
+	m1 := rwctest.NewBufRWC()
+	m2 := rwctest.NewBufRWC()
+	r := rwc.NewResReadWriteCloser(m1)
+
+	if err := r.ResetContext(context.Background(), m2, true); err != nil {
+		t.Fatalf("unexpected reset error: %v", err)
+	}
+	if r.ResetCount() != 1 {
+		t.Fatalf("expected ResetCount 1, got %d", r.ResetCount())
+	}
+}
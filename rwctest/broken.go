@@ -0,0 +1,93 @@
+package rwctest
+
+import (
+	"io"
+	"sync"
+)
+
+// BrokenRWC is an io.ReadWriteCloser that returns a configured error after a
+// certain number of bytes have been read and/or written, or immediately if
+// that number is 0. It is intended for exercising error paths in code built
+// on top of rwc.ResReadWriteCloser. It is safe for concurrent use.
+type BrokenRWC struct {
+	mu sync.Mutex
+
+	readAfter  int
+	readErr    error
+	writeAfter int
+	writeErr   error
+
+	readN  int
+	writeN int
+}
+
+// NewBrokenRWC returns a BrokenRWC that returns readErr once readAfter bytes
+// have been read, and writeErr once writeAfter bytes have been written.
+// writeErr may be nil to leave writes always successful. readErr may also be
+// nil, in which case Read returns io.EOF immediately instead of the
+// zero-byte, nil-error result an io.Reader must never return.
+func NewBrokenRWC(readAfter int, readErr error, writeAfter int, writeErr error) *BrokenRWC {
+	return &BrokenRWC{readAfter: readAfter, readErr: readErr, writeAfter: writeAfter, writeErr: writeErr}
+}
+
+// Read implements the io.Reader interface. It returns zero-valued bytes
+// until readAfter have been returned in total; the call that reaches
+// readAfter returns readErr along with its bytes, and every later call
+// returns 0 and readErr. If readErr is
+// nil, Read returns io.EOF immediately rather than leaving that direction
+// unaffected, since an io.Reader returning (0, nil) forever violates the
+// io.Reader contract and busy-loops callers such as io.Copy.
+func (b *BrokenRWC) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.readErr == nil {
+		return 0, io.EOF
+	}
+	if b.readN >= b.readAfter {
+		return 0, b.readErr
+	}
+
+	n := b.readAfter - b.readN
+	if n > len(p) {
+		n = len(p)
+	}
+	for i := range p[:n] {
+		p[i] = 0
+	}
+	b.readN += n
+	if b.readN >= b.readAfter {
+		return n, b.readErr
+	}
+	return n, nil
+}
+
+// Write implements the io.Writer interface. It accepts up to writeAfter
+// bytes in total; the call that reaches writeAfter returns writeErr along
+// with its count, and every later call returns 0 and writeErr.
+func (b *BrokenRWC) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.writeErr == nil {
+		return len(p), nil
+	}
+	if b.writeN >= b.writeAfter {
+		return 0, b.writeErr
+	}
+
+	n := b.writeAfter - b.writeN
+	if n > len(p) {
+		n = len(p)
+	}
+	b.writeN += n
+	if b.writeN >= b.writeAfter {
+		return n, b.writeErr
+	}
+	return n, nil
+}
+
+// Close implements the io.Closer interface. BrokenRWC never fails to close.
+func (b *BrokenRWC) Close() error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package rwctest
+
+import (
+	"io"
+	"time"
+)
+
+// DelayRWC wraps another io.ReadWriteCloser, sleeping for Delay before each
+// Read and Write call. It is useful for deterministically exercising
+// reset-during-IO races against a rwc.ResReadWriteCloser.
+type DelayRWC struct {
+	io.ReadWriteCloser
+	Delay time.Duration
+}
+
+// NewDelayRWC returns a DelayRWC wrapping rwc, sleeping for delay before
+// every Read and Write.
+func NewDelayRWC(rwc io.ReadWriteCloser, delay time.Duration) *DelayRWC {
+	return &DelayRWC{ReadWriteCloser: rwc, Delay: delay}
+}
+
+// Read implements the io.Reader interface, sleeping for Delay first.
+func (d *DelayRWC) Read(p []byte) (int, error) {
+	time.Sleep(d.Delay)
+	return d.ReadWriteCloser.Read(p)
+}
+
+// Write implements the io.Writer interface, sleeping for Delay first.
+func (d *DelayRWC) Write(p []byte) (int, error) {
+	time.Sleep(d.Delay)
+	return d.ReadWriteCloser.Write(p)
+}
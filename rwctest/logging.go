@@ -0,0 +1,41 @@
+package rwctest
+
+import (
+	"io"
+	"sync"
+)
+
+// LoggingRWC wraps another io.ReadWriteCloser, teeing all bytes read and
+// written to W for debugging. It is safe for concurrent use.
+type LoggingRWC struct {
+	io.ReadWriteCloser
+	W  io.Writer
+	mu sync.Mutex
+}
+
+// NewLoggingRWC returns a LoggingRWC wrapping rwc, teeing all IO to w.
+func NewLoggingRWC(rwc io.ReadWriteCloser, w io.Writer) *LoggingRWC {
+	return &LoggingRWC{ReadWriteCloser: rwc, W: w}
+}
+
+// Read implements the io.Reader interface, teeing the bytes read to W.
+func (l *LoggingRWC) Read(p []byte) (int, error) {
+	n, err := l.ReadWriteCloser.Read(p)
+	if n > 0 {
+		l.mu.Lock()
+		_, _ = l.W.Write(p[:n])
+		l.mu.Unlock()
+	}
+	return n, err
+}
+
+// Write implements the io.Writer interface, teeing the bytes written to W.
+func (l *LoggingRWC) Write(p []byte) (int, error) {
+	n, err := l.ReadWriteCloser.Write(p)
+	if n > 0 {
+		l.mu.Lock()
+		_, _ = l.W.Write(p[:n])
+		l.mu.Unlock()
+	}
+	return n, err
+}
@@ -0,0 +1,43 @@
+package rwctest
+
+import "io"
+
+// pipeRWC implements io.ReadWriteCloser over a pair of io.Pipe halves, one
+// per direction, so each side can be read from and written to
+// independently.
+type pipeRWC struct {
+	r *io.PipeReader
+	w *io.PipeWriter
+}
+
+// Read implements the io.Reader interface.
+func (p *pipeRWC) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+// Write implements the io.Writer interface.
+func (p *pipeRWC) Write(b []byte) (int, error) {
+	return p.w.Write(b)
+}
+
+// Close implements the io.Closer interface, closing both pipe halves.
+func (p *pipeRWC) Close() error {
+	err := p.r.Close()
+	if werr := p.w.Close(); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// PipeRWC returns a pair of connected io.ReadWriteCloser values, client and
+// server, backed by an io.Pipe in each direction: bytes written to client
+// are read from server, and bytes written to server are read from client.
+// Both ends are safe for concurrent use by multiple goroutines, same as
+// io.Pipe, and are useful for simulating a bidirectional stream in tests.
+func PipeRWC() (client, server io.ReadWriteCloser) {
+	cToSR, cToSW := io.Pipe()
+	sToCR, sToCW := io.Pipe()
+	client = &pipeRWC{r: sToCR, w: cToSW}
+	server = &pipeRWC{r: cToSR, w: sToCW}
+	return client, server
+}
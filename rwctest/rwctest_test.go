@@ -0,0 +1,105 @@
+package rwctest_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tech10/rwc/rwctest"
+)
+
+func TestBufRWC(t *testing.T) {
+	b := rwctest.NewBufRWC()
+
+	n, err := b.Write([]byte("hello"))
+	if err != nil || n != 5 {
+		t.Fatalf("unexpected write: n=%d, err=%v", n, err)
+	}
+
+	buf := make([]byte, 5)
+	n, err = b.Read(buf)
+	if err != nil || n != 5 || string(buf) != "hello" {
+		t.Fatalf("unexpected read: n=%d, err=%v, buf=%s", n, err, buf)
+	}
+
+	if b.Closed() {
+		t.Fatal("expected BufRWC not to be closed yet")
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if !b.Closed() {
+		t.Fatal("expected BufRWC to be closed")
+	}
+	if _, err := b.Write([]byte("x")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("expected io.ErrClosedPipe after close, got %v", err)
+	}
+}
+
+func TestBrokenRWC(t *testing.T) {
+	errRead := errors.New("read broken")
+	errWrite := errors.New("write broken")
+	b := rwctest.NewBrokenRWC(3, errRead, 2, errWrite)
+
+	buf := make([]byte, 10)
+	n, err := b.Read(buf)
+	if n != 3 || !errors.Is(err, errRead) {
+		t.Fatalf("expected n=3, err=%v, got n=%d, err=%v", errRead, n, err)
+	}
+
+	n, err = b.Write([]byte("abcdef"))
+	if n != 2 || !errors.Is(err, errWrite) {
+		t.Fatalf("expected n=2, err=%v, got n=%d, err=%v", errWrite, n, err)
+	}
+}
+
+func TestDelayRWC(t *testing.T) {
+	b := rwctest.NewBufRWC()
+	_, _ = b.Write([]byte("hi"))
+	d := rwctest.NewDelayRWC(b, 20*time.Millisecond)
+
+	start := time.Now()
+	buf := make([]byte, 2)
+	if _, err := d.Read(buf); err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected Read to be delayed by at least 20ms, took %v", elapsed)
+	}
+}
+
+func TestLoggingRWC(t *testing.T) {
+	b := rwctest.NewBufRWC()
+	var logged bytes.Buffer
+	l := rwctest.NewLoggingRWC(b, &logged)
+
+	if _, err := l.Write([]byte("logged")); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if logged.String() != "logged" {
+		t.Fatalf("expected log to capture %q, got %q", "logged", logged.String())
+	}
+}
+
+func TestPipeRWC(t *testing.T) {
+	client, server := rwctest.PipeRWC()
+	defer client.Close()
+	defer server.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 5)
+		n, err := server.Read(buf)
+		if err != nil || n != 5 || string(buf) != "hello" {
+			t.Errorf("unexpected server read: n=%d, err=%v, buf=%s", n, err, buf)
+		}
+	}()
+
+	if _, err := client.Write([]byte("hello")); err != nil {
+		t.Fatalf("unexpected client write error: %v", err)
+	}
+	<-done
+}